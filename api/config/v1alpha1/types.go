@@ -0,0 +1,128 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+)
+
+// AWSConfiguration configures the default AWS account the controller talks
+// to. Individual resources (ACMCertificateImport.Spec.AWS, ACMIssuer) may
+// override Region.
+type AWSConfiguration struct {
+	// Region is the AWS region used when a resource doesn't set its own.
+	Region string `json:"region,omitempty"`
+
+	// Endpoint overrides the ACM/ACM-PCA API endpoint, useful for testing
+	// against LocalStack or a VPC endpoint.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// CredentialSource selects how AWS credentials are obtained: "irsa" uses
+	// the IAM role bound to the pod's ServiceAccount, "static" reads the
+	// AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY environment variables, and
+	// "profile" reads Profile from the shared credentials file. Defaults to "irsa".
+	CredentialSource string `json:"credentialSource,omitempty"`
+
+	// Profile is the named profile to use when CredentialSource is "profile".
+	Profile string `json:"profile,omitempty"`
+
+	// Tags are applied to every certificate this controller imports or
+	// requests in ACM, in addition to any tags set on the resource.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ControllerConfigurationSpec configures a single controller's concurrency
+// and resync behaviour.
+type ControllerConfigurationSpec struct {
+	// WorkerCount is the number of concurrent reconciles this controller
+	// runs. Defaults to 1.
+	WorkerCount int `json:"workerCount,omitempty"`
+
+	// ResyncPeriod is how often objects are re-reconciled even without a
+	// change. Defaults to 10m.
+	ResyncPeriod metav1.Duration `json:"resyncPeriod,omitempty"`
+}
+
+// ControllersConfiguration configures each controller registered by the manager.
+type ControllersConfiguration struct {
+	ACMCertificateImport ControllerConfigurationSpec `json:"acmCertificateImport,omitempty"`
+	ACMIssuer            ControllerConfigurationSpec `json:"acmIssuer,omitempty"`
+	CertificateRequest   ControllerConfigurationSpec `json:"certificateRequest,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ControllerConfiguration is the Schema used to configure the
+// kube-acm-importer controller manager. It is loaded from the file passed
+// via --config, following the same ComponentConfig pattern cert-manager uses.
+type ControllerConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ControllerManagerConfigurationSpec carries the common manager knobs:
+	// leader election, health/metrics bind addresses, graceful shutdown, sync period.
+	cfg.ControllerManagerConfigurationSpec `json:",inline"`
+
+	// AWS configures the default AWS account, region and credential source.
+	AWS AWSConfiguration `json:"aws,omitempty"`
+
+	// Controllers configures the worker count and resync period of each controller.
+	Controllers ControllersConfiguration `json:"controllers,omitempty"`
+
+	// WatchNamespaces restricts the manager's cache to the given namespaces.
+	// If empty, all namespaces are watched.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ControllerConfiguration{})
+}
+
+// Default applies the kube-acm-importer defaults, it is called after the
+// config file has been unmarshalled and before CLI flag overrides are applied.
+func (c *ControllerConfiguration) Default() {
+	if c.AWS.CredentialSource == "" {
+		c.AWS.CredentialSource = "irsa"
+	}
+
+	for _, spec := range []*ControllerConfigurationSpec{&c.Controllers.ACMCertificateImport, &c.Controllers.ACMIssuer, &c.Controllers.CertificateRequest} {
+		if spec.WorkerCount == 0 {
+			spec.WorkerCount = 1
+		}
+		if spec.ResyncPeriod.Duration == 0 {
+			spec.ResyncPeriod = metav1.Duration{Duration: 10 * time.Minute}
+		}
+	}
+}
+
+// Validate checks that c contains a usable configuration.
+func (c *ControllerConfiguration) Validate() error {
+	switch c.AWS.CredentialSource {
+	case "irsa", "static", "profile":
+	default:
+		return fmt.Errorf("aws.credentialSource must be one of irsa, static or profile, got %q", c.AWS.CredentialSource)
+	}
+
+	if c.AWS.CredentialSource == "profile" && c.AWS.Profile == "" {
+		return fmt.Errorf("aws.profile is required when aws.credentialSource is \"profile\"")
+	}
+
+	return nil
+}
@@ -32,12 +32,53 @@ type ACMCertificateImportSpec struct {
 	// ServiceRefs are services that should be updated with the ACM annotation to have AWS use the certificate for their
 	// load balancer.
 	ServiceRefs []corev1.LocalObjectReference `json:"serviceRefs,omitempty"`
+	// IngressRefs are ALB Ingresses that should be updated with the ACM certificate ARN so AWS Load Balancer
+	// Controller terminates TLS for them using this certificate.
+	IngressRefs []corev1.LocalObjectReference `json:"ingressRefs,omitempty"`
+	// GatewayRefs are Gateway API Gateways whose HTTPS/TLS listeners should be updated to use the ACM certificate.
+	GatewayRefs []corev1.LocalObjectReference `json:"gatewayRefs,omitempty"`
+	// AWS targets the account and region the certificate is imported into. If unset, the controller's own
+	// default account/region is used.
+	AWS *ACMCertificateImportAWS `json:"aws,omitempty"`
+	// RenewBefore is how long before the imported certificate's NotAfter the controller expects a rotated
+	// certificate to have landed in the Secret. If it hasn't, the Rotating condition is set to True so the
+	// stall can be alerted on. Defaults to 30 days.
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+	// TrustBundleRef references a Secret containing the CA certificates (under the "ca.crt" key) the
+	// certificate chain in SecretRef is verified against. If unset, the chain is verified against its own
+	// intermediates, since certificates imported here are commonly signed by private CAs.
+	TrustBundleRef *corev1.LocalObjectReference `json:"trustBundleRef,omitempty"`
+	// DryRun validates the certificate in SecretRef against ACM's import rules without actually importing
+	// it, useful for checking a Secret ahead of a migration from another issuer.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ACMCertificateImportAWS configures which AWS account and region a certificate is imported into.
+type ACMCertificateImportAWS struct {
+	// ProfileRef references a cluster-scoped ACMProfile providing default Region/RoleARN/Endpoint/Tags.
+	// Fields set directly below take precedence over the profile's values.
+	ProfileRef *corev1.LocalObjectReference `json:"profileRef,omitempty"`
+	// Region is the AWS region to import the certificate into. Defaults to the controller's configured region.
+	Region string `json:"region,omitempty"`
+	// RoleARN is an IAM role the controller assumes via STS AssumeRole before calling ACM, allowing a single
+	// controller instance to import certificates into many AWS accounts.
+	RoleARN string `json:"roleARN,omitempty"`
+	// Endpoint overrides the ACM API endpoint.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Tags are applied to the certificate when it is imported into ACM.
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 // ACMCertificateImportStatus defines the observed state of ACMCertificateImport
 type ACMCertificateImportStatus struct {
 	ARN          *string `json:"arn,omitempty"`
 	SerialNumber string  `json:"serialNumber"`
+	// NotBefore is the imported leaf certificate's validity start time.
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+	// NotAfter is the imported leaf certificate's expiry time.
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// Conditions is a list of status conditions the import is in, notably Ready and Rotating.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
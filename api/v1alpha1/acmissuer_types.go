@@ -0,0 +1,103 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionReady indicates the issuer has validated its configuration (AWS
+// region and credentials) and can be used to issue certificates.
+const ConditionReady = "Ready"
+
+// ConditionRotating indicates an ACMCertificateImport's certificate is due
+// for renewal but a rotated certificate hasn't landed in its Secret yet.
+const ConditionRotating = "Rotating"
+
+// ConditionDegraded indicates an ACMCertificateImport's Secret failed
+// validation and was not imported into ACM.
+const ConditionDegraded = "Degraded"
+
+// ACMIssuerSpec defines the desired state of ACMIssuer
+type ACMIssuerSpec struct {
+	// Region is the AWS region certificates are requested in. If empty the
+	// region configured on the controller is used.
+	Region string `json:"region,omitempty"`
+
+	// CertificateAuthorityARN is the ARN of an ACM Private CA to issue
+	// certificates from. CertificateRequests referencing an issuer without it
+	// are not issued: they stay Pending with a message naming the missing
+	// field. ACM's public certificate issuance API is not used.
+	CertificateAuthorityARN *string `json:"certificateAuthorityARN,omitempty"`
+}
+
+// ACMIssuerStatus defines the observed state of ACMIssuer
+type ACMIssuerStatus struct {
+	// Conditions is a list of status conditions the issuer is in, notably Ready.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ACMIssuer is the Schema for the acmissuers API. It allows cert-manager
+// CertificateRequest resources to be satisfied by AWS Certificate Manager,
+// acting as an external cert-manager issuer.
+type ACMIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ACMIssuerSpec   `json:"spec,omitempty"`
+	Status ACMIssuerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ACMIssuerList contains a list of ACMIssuer
+type ACMIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ACMIssuer `json:"items"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// ACMClusterIssuer is the cluster-scoped equivalent of ACMIssuer, it can be
+// referenced by CertificateRequests in any namespace.
+type ACMClusterIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ACMIssuerSpec   `json:"spec,omitempty"`
+	Status ACMIssuerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ACMClusterIssuerList contains a list of ACMClusterIssuer
+type ACMClusterIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ACMClusterIssuer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ACMIssuer{}, &ACMIssuerList{})
+	SchemeBuilder.Register(&ACMClusterIssuer{}, &ACMClusterIssuerList{})
+}
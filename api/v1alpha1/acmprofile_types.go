@@ -0,0 +1,60 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ACMProfileSpec defines the common AWS account/region settings an
+// ACMCertificateImport can reference by name instead of repeating them.
+type ACMProfileSpec struct {
+	// Region is the AWS region to import certificates into.
+	Region string `json:"region,omitempty"`
+	// RoleARN is an IAM role the controller assumes via STS AssumeRole before calling ACM.
+	RoleARN string `json:"roleARN,omitempty"`
+	// Endpoint overrides the ACM API endpoint.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Tags are applied to every certificate imported using this profile.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ACMProfile is the Schema for the acmprofiles API. It is a cluster-scoped
+// collection of AWS account/region/tag settings that can be shared between
+// ACMCertificateImport resources via ACMCertificateImportAWS.ProfileRef.
+type ACMProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ACMProfileSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ACMProfileList contains a list of ACMProfile
+type ACMProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ACMProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ACMProfile{}, &ACMProfileList{})
+}
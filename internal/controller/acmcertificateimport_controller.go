@@ -21,26 +21,37 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/acm"
-	"github.com/aws/aws-sdk-go/service/acm/acmiface"
 	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	networkingv1 "k8s.io/api/networking/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/cert"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	acmv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/v1alpha1"
 )
 
+// defaultRenewBefore is used when Spec.RenewBefore is unset.
+const defaultRenewBefore = 30 * 24 * time.Hour
+
+// rotationPollInterval is how often we requeue once a certificate is past
+// its renewal point but the Secret hasn't rotated yet.
+const rotationPollInterval = time.Hour
+
 const ServiceAnnotation = "service.beta.kubernetes.io/aws-load-balancer-ssl-cert"
 const Finalizer = "acm.kubespress.com/imported"
 const FieldOwner = "acm.kubespress.com"
@@ -48,13 +59,33 @@ const FieldOwner = "acm.kubespress.com"
 // ACMCertificateImportReconciler reconciles a ACMCertificateImport object
 type ACMCertificateImportReconciler struct {
 	client.Client
-	ACM    acmiface.ACMAPI
-	Scheme *runtime.Scheme
+	// Clients resolves the acmiface.ACMAPI client to use for a given
+	// ACMCertificateImport, based on its (possibly profile-derived) AWS
+	// account/region settings.
+	Clients  *ClientFactory
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// DefaultTags are applied to every certificate this controller imports, sourced from
+	// ControllerConfiguration.AWS.Tags. ACMProfile and Spec.AWS.Tags take precedence over these.
+	DefaultTags map[string]string
+
+	// WorkerCount is the number of concurrent reconciles to run, sourced from
+	// ControllerConfiguration.Controllers.ACMCertificateImport.WorkerCount. A
+	// value of zero defaults to 1.
+	WorkerCount int
+
+	// ResyncPeriod causes a periodic reconcile even without a triggering change, sourced from
+	// ControllerConfiguration.Controllers.ACMCertificateImport.ResyncPeriod. Zero disables it.
+	ResyncPeriod time.Duration
 }
 
 //+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmcertificateimports,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmcertificateimports/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmcertificateimports/finalizers,verbs=update
+//+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -79,16 +110,19 @@ func (r *ACMCertificateImportReconciler) Reconcile(ctx context.Context, req ctrl
 	}
 
 	// Ensure the certificate is up to date in ACM
-	if err := r.ensureCertificateUpdated(ctx, &certificateImport); err != nil {
+	result, err := r.ensureCertificateUpdated(ctx, &certificateImport)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Update the service annotations
-	if err := r.ensureServiceAnnotations(ctx, &certificateImport); err != nil {
-		return ctrl.Result{}, err
+	// Update the Service/Ingress/Gateway annotation targets
+	if arn := pointer.StringDeref(certificateImport.Status.ARN, ""); arn != "" {
+		if err := r.ensureAnnotationTargets(ctx, &certificateImport, arn); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	return ctrl.Result{}, nil
+	return requeueOrResync(result, nil, r.ResyncPeriod)
 }
 
 func (r *ACMCertificateImportReconciler) ensureFinalizer(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) error {
@@ -101,33 +135,79 @@ func (r *ACMCertificateImportReconciler) ensureFinalizer(ctx context.Context, ce
 	return nil
 }
 
-func (r *ACMCertificateImportReconciler) ensureCertificateUpdated(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) error {
+func (r *ACMCertificateImportReconciler) ensureCertificateUpdated(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) (ctrl.Result, error) {
 	// Don't update the cert if we are frozen
 	if pointer.BoolDeref(certificateImport.Spec.Frozen, false) {
-		return nil
+		return ctrl.Result{}, nil
+	}
+
+	// Resolve which AWS account/region this import targets
+	target, err := r.resolveTarget(ctx, certificateImport)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
 	// Get desired cert from the secret
 	certs, key, err := r.getCertificatesFromSecret(ctx, certificateImport)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
+	leaf := certs[0]
 
-	// If the serial numbers match, do nothing
-	if certificateImport.Status.SerialNumber == certs[0].SerialNumber.String() {
-		return nil
+	// If the serial numbers match, the certificate is already imported, so just check whether it's due for renewal
+	if certificateImport.Status.SerialNumber == leaf.SerialNumber.String() {
+		return r.ensureRotationStatus(ctx, certificateImport, leaf)
+	}
+
+	// Validate the certificate against ACM's import rules before going any further
+	trustBundle, err := r.resolveTrustBundle(ctx, certificateImport)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if verr := validateCertificateForACM(certs, key, trustBundle); verr != nil {
+		apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+			Type:    acmv1alpha1.ConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  verr.reason,
+			Message: verr.message,
+		})
+		if err := r.Status().Update(ctx, certificateImport); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.event(certificateImport, corev1.EventTypeWarning, verr.reason, "certificate failed validation: %s", verr.message)
+		return ctrl.Result{}, nil
+	}
+	apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+		Type:    acmv1alpha1.ConditionDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Validated",
+		Message: "the certificate passed ACM's import validation",
+	})
+
+	// In dry-run mode, stop after validation without importing or recording the new serial, so the Secret
+	// keeps being validated on every subsequent reconcile until DryRun is turned off
+	if certificateImport.Spec.DryRun {
+		certificateImport.Status.NotBefore = &metav1.Time{Time: leaf.NotBefore}
+		certificateImport.Status.NotAfter = &metav1.Time{Time: leaf.NotAfter}
+		apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+			Type:    acmv1alpha1.ConditionReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "DryRun",
+			Message: "the certificate passed validation but was not imported because DryRun is set",
+		})
+		return ctrl.Result{}, r.Status().Update(ctx, certificateImport)
 	}
 
 	// Encode the cert as a PEM
-	certPem, err := cert.EncodeCertificates(certs[0])
+	certPem, err := cert.EncodeCertificates(leaf)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
 	// Encode the chain as a PEM
 	chainPem, err := cert.EncodeCertificates(certs[1:]...)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
 	// Input for the import
@@ -136,62 +216,185 @@ func (r *ACMCertificateImportReconciler) ensureCertificateUpdated(ctx context.Co
 		Certificate:      certPem,
 		CertificateChain: chainPem,
 		PrivateKey:       key,
+		Tags:             target.tags(),
 	}
 
 	// Get the result
-	log.FromContext(ctx).Info("importing certificate into acm", "arn", certificateImport.Status.ARN)
-	output, err := r.ACM.ImportCertificate(&input)
+	log.FromContext(ctx).Info("importing certificate into acm", "arn", certificateImport.Status.ARN, "region", target.region, "roleARN", target.roleARN)
+	output, err := r.Clients.Get(target.region, target.roleARN, target.endpoint).ImportCertificate(&input)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
-	// Update the object with the new ARN / Serial
+	// Update the object with the new ARN / Serial / validity window
+	rotated := certificateImport.Status.ARN != nil
 	certificateImport.Status.ARN = output.CertificateArn
-	certificateImport.Status.SerialNumber = certs[0].SerialNumber.String()
+	certificateImport.Status.SerialNumber = leaf.SerialNumber.String()
+	certificateImport.Status.NotBefore = &metav1.Time{Time: leaf.NotBefore}
+	certificateImport.Status.NotAfter = &metav1.Time{Time: leaf.NotAfter}
+	apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+		Type:    acmv1alpha1.ConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Imported",
+		Message: "the certificate has been imported into ACM",
+	})
+	apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+		Type:    acmv1alpha1.ConditionRotating,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Imported",
+		Message: "a current certificate is imported into ACM",
+	})
 	if err := r.Status().Update(ctx, certificateImport); err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
-	// Return no error
-	return nil
+	if rotated {
+		r.event(certificateImport, corev1.EventTypeNormal, "Rotated", "certificate rotated, new serial %s imported as %s", leaf.SerialNumber.String(), pointer.StringDeref(output.CertificateArn, ""))
+	} else {
+		r.event(certificateImport, corev1.EventTypeNormal, "Imported", "certificate with serial %s imported as %s", leaf.SerialNumber.String(), pointer.StringDeref(output.CertificateArn, ""))
+	}
+
+	return r.requeueForRenewal(certificateImport, leaf), nil
+}
+
+// ensureRotationStatus checks whether leaf is due for renewal, setting the
+// Rotating condition and requeuing around the renewal time if so. It is
+// called when the Secret's certificate hasn't changed since the last import.
+func (r *ACMCertificateImportReconciler) ensureRotationStatus(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport, leaf *x509.Certificate) (ctrl.Result, error) {
+	renewAt := leaf.NotAfter.Add(-renewBeforeOrDefault(certificateImport.Spec.RenewBefore))
+
+	if time.Now().Before(renewAt) {
+		apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+			Type:    acmv1alpha1.ConditionRotating,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Imported",
+			Message: "a current certificate is imported into ACM",
+		})
+		if err := r.Status().Update(ctx, certificateImport); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Until(renewAt)}, nil
+	}
+
+	// The certificate is past its renewal point but the Secret hasn't rotated yet, flag it so the stall is visible
+	wasRotating := apimeta.IsStatusConditionTrue(certificateImport.Status.Conditions, acmv1alpha1.ConditionRotating)
+	apimeta.SetStatusCondition(&certificateImport.Status.Conditions, metav1.Condition{
+		Type:    acmv1alpha1.ConditionRotating,
+		Status:  metav1.ConditionTrue,
+		Reason:  "RenewalDue",
+		Message: fmt.Sprintf("certificate is due for renewal, waiting for a rotated certificate to land in secret %q", certificateImport.Spec.SecretRef.Name),
+	})
+	if err := r.Status().Update(ctx, certificateImport); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !wasRotating {
+		r.event(certificateImport, corev1.EventTypeWarning, "RenewalDue", "certificate is due for renewal, waiting for secret %q to rotate", certificateImport.Spec.SecretRef.Name)
+	}
+
+	return ctrl.Result{RequeueAfter: rotationPollInterval}, nil
 }
 
-func (r *ACMCertificateImportReconciler) ensureServiceAnnotations(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) error {
-	// Get the ARN as a string, if it is not set there is nothing to do
-	arn := pointer.StringDeref(certificateImport.Status.ARN, "")
-	if arn == "" {
+// requeueForRenewal returns a Result that requeues certificateImport around
+// the time its just-imported certificate becomes due for renewal.
+func (r *ACMCertificateImportReconciler) requeueForRenewal(certificateImport *acmv1alpha1.ACMCertificateImport, leaf *x509.Certificate) ctrl.Result {
+	renewAt := leaf.NotAfter.Add(-renewBeforeOrDefault(certificateImport.Spec.RenewBefore))
+	if until := time.Until(renewAt); until > 0 {
+		return ctrl.Result{RequeueAfter: until}
+	}
+	return ctrl.Result{RequeueAfter: rotationPollInterval}
+}
+
+// renewBeforeOrDefault returns d.Duration, or defaultRenewBefore if d is nil.
+func renewBeforeOrDefault(d *metav1.Duration) time.Duration {
+	if d == nil {
+		return defaultRenewBefore
+	}
+	return d.Duration
+}
+
+// event records a Kubernetes Event against certificateImport, if a Recorder is configured.
+func (r *ACMCertificateImportReconciler) event(certificateImport *acmv1alpha1.ACMCertificateImport, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(certificateImport, eventType, reason, messageFmt, args...)
+}
+
+// importTarget is the resolved AWS account/region/tags an
+// ACMCertificateImport should be imported into, merged from its ACMProfile
+// (if any) and its own inline overrides.
+type importTarget struct {
+	region   string
+	roleARN  string
+	endpoint string
+	tagMap   map[string]string
+}
+
+// tags converts tagMap into the []*acm.Tag shape ImportCertificateInput expects.
+func (t importTarget) tags() []*acm.Tag {
+	if len(t.tagMap) == 0 {
 		return nil
 	}
 
-	// Track errors
-	var errors []error
+	tags := make([]*acm.Tag, 0, len(t.tagMap))
+	for k, v := range t.tagMap {
+		tags = append(tags, &acm.Tag{Key: pointer.String(k), Value: pointer.String(v)})
+	}
+	return tags
+}
 
-	// Loop over references
-	for _, serviceRef := range certificateImport.Spec.ServiceRefs {
-		// Get the service
-		var service corev1.Service
-		if err := r.Get(ctx, client.ObjectKey{Namespace: certificateImport.Namespace, Name: serviceRef.Name}, &service); err != nil {
-			errors = append(errors, fmt.Errorf("could not get service %q: %w", serviceRef.Name, err))
-			continue
-		}
+// resolveTarget merges the ACMProfile referenced by certificateImport (if
+// any) with its inline Spec.AWS overrides, which take precedence.
+func (r *ACMCertificateImportReconciler) resolveTarget(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) (importTarget, error) {
+	var target importTarget
+	target.tagMap = mergeTags(nil, r.DefaultTags)
 
-		// If the annotation is already set, don't update
-		if _, exists := service.Annotations[ServiceAnnotation]; exists {
-			continue
-		}
+	aws := certificateImport.Spec.AWS
+	if aws == nil {
+		return target, nil
+	}
 
-		// Use patch to set the annotation
-		annoationKeyEscaped := ServiceAnnotation
-		annoationKeyEscaped = strings.ReplaceAll(annoationKeyEscaped, "~", "~0")
-		annoationKeyEscaped = strings.ReplaceAll(annoationKeyEscaped, "/", "~1")
-		jsonPatch := fmt.Sprintf(`[{"op": "replace", "path": "/metadata/annotations/%s", "value": %q}]`, annoationKeyEscaped, arn)
-		if err := r.Patch(ctx, &service, client.RawPatch(types.JSONPatchType, []byte(jsonPatch)), client.FieldOwner(FieldOwner)); err != nil {
-			errors = append(errors, fmt.Errorf("could not patch service %q annotation: %w", serviceRef.Name, err))
+	if aws.ProfileRef != nil {
+		var profile acmv1alpha1.ACMProfile
+		if err := r.Get(ctx, client.ObjectKey{Name: aws.ProfileRef.Name}, &profile); err != nil {
+			return target, fmt.Errorf("could not get ACMProfile %q: %w", aws.ProfileRef.Name, err)
 		}
+
+		target.region = profile.Spec.Region
+		target.roleARN = profile.Spec.RoleARN
+		target.endpoint = profile.Spec.Endpoint
+		target.tagMap = mergeTags(target.tagMap, profile.Spec.Tags)
+	}
+
+	if aws.Region != "" {
+		target.region = aws.Region
+	}
+	if aws.RoleARN != "" {
+		target.roleARN = aws.RoleARN
+	}
+	if aws.Endpoint != "" {
+		target.endpoint = aws.Endpoint
+	}
+	target.tagMap = mergeTags(target.tagMap, aws.Tags)
+
+	return target, nil
+}
+
+// mergeTags returns base with override's entries applied on top. Neither argument is mutated.
+func mergeTags(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
 	}
 
-	// Return aggregate errors
-	return utilerrors.NewAggregate(errors)
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
 }
 
 func (r *ACMCertificateImportReconciler) reconcileDelete(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) (ctrl.Result, error) {
@@ -206,8 +409,14 @@ func (r *ACMCertificateImportReconciler) reconcileDelete(ctx context.Context, ce
 		return ctrl.Result{}, nil
 	}
 
-	// Remove service annotations
-	if err := r.removeServiceAnnotations(ctx, certificateImport); err != nil {
+	// Remove the Service/Ingress/Gateway annotation targets
+	if err := r.removeAnnotationTargets(ctx, certificateImport, pointer.StringDeref(certificateImport.Status.ARN, "")); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Resolve which AWS account/region this import was created in
+	target, err := r.resolveTarget(ctx, certificateImport)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -217,7 +426,7 @@ func (r *ACMCertificateImportReconciler) reconcileDelete(ctx context.Context, ce
 	}
 
 	// Perform DeleteCertificate API call
-	_, err := r.ACM.DeleteCertificate(&input)
+	_, err = r.Clients.Get(target.region, target.roleARN, target.endpoint).DeleteCertificate(&input)
 
 	// If the error is that the resource is not found, we can just continue
 	var awsErr awserr.Error
@@ -231,50 +440,11 @@ func (r *ACMCertificateImportReconciler) reconcileDelete(ctx context.Context, ce
 	}
 
 	// Update the object status, removing the ARN, this will trigger another reconcile that will remove the finalizer
+	r.event(certificateImport, corev1.EventTypeNormal, "Deleted", "certificate %s deleted from ACM", pointer.StringDeref(certificateImport.Status.ARN, ""))
 	certificateImport.Status.ARN = nil
 	return ctrl.Result{}, r.Status().Update(ctx, certificateImport)
 }
 
-func (r *ACMCertificateImportReconciler) removeServiceAnnotations(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) error {
-	// Get the ARN as a string, if it is not set there is nothing to do
-	arn := pointer.StringDeref(certificateImport.Status.ARN, "")
-	if arn == "" {
-		return nil
-	}
-
-	// Track errors
-	var errors []error
-
-	// Loop over references
-	for _, serviceRef := range certificateImport.Spec.ServiceRefs {
-		// Get the service
-		var service corev1.Service
-		if err := r.Get(ctx, client.ObjectKey{Namespace: certificateImport.Namespace, Name: serviceRef.Name}, &service); err != nil {
-			if !apierrors.IsNotFound(err) {
-				errors = append(errors, fmt.Errorf("could not get service %q: %w", serviceRef.Name, err))
-			}
-			continue
-		}
-
-		// Only remove the annotation if its the one we manage
-		if service.Annotations[ServiceAnnotation] != arn {
-			continue
-		}
-
-		// Use patch to delete the annotation
-		annoationKeyEscaped := ServiceAnnotation
-		annoationKeyEscaped = strings.ReplaceAll(annoationKeyEscaped, "~", "~0")
-		annoationKeyEscaped = strings.ReplaceAll(annoationKeyEscaped, "/", "~1")
-		jsonPatch := fmt.Sprintf(`[{"op": "remove", "path": "/metadata/annotations/%s"}]`, annoationKeyEscaped)
-		if err := r.Patch(ctx, &service, client.RawPatch(types.JSONPatchType, []byte(jsonPatch)), client.FieldOwner(FieldOwner)); err != nil {
-			errors = append(errors, fmt.Errorf("could not patch service %q annotation: %w", serviceRef.Name, err))
-		}
-	}
-
-	// Return aggregate errors
-	return utilerrors.NewAggregate(errors)
-}
-
 func (r *ACMCertificateImportReconciler) getCertificatesFromSecret(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) ([]*x509.Certificate, []byte, error) {
 	// Get the secret
 	var secret corev1.Secret
@@ -296,5 +466,78 @@ func (r *ACMCertificateImportReconciler) getCertificatesFromSecret(ctx context.C
 func (r *ACMCertificateImportReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&acmv1alpha1.ACMCertificateImport{}).
+		Watches(&source.Kind{Type: &networkingv1.Ingress{}}, handler.EnqueueRequestsFromMapFunc(r.mapIngressToCertificateImports)).
+		Watches(&source.Kind{Type: &gatewayv1.Gateway{}}, handler.EnqueueRequestsFromMapFunc(r.mapGatewayToCertificateImports)).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToCertificateImports)).
+		WithOptions(ctrlcontroller.Options{MaxConcurrentReconciles: workerCountOrDefault(r.WorkerCount)}).
 		Complete(r)
 }
+
+// mapIngressToCertificateImports enqueues every ACMCertificateImport in obj's
+// namespace that references it as an IngressRef.
+func (r *ACMCertificateImportReconciler) mapIngressToCertificateImports(obj client.Object) []ctrl.Request {
+	return r.mapRefToCertificateImports(obj, func(certificateImport acmv1alpha1.ACMCertificateImport) []corev1.LocalObjectReference {
+		return certificateImport.Spec.IngressRefs
+	})
+}
+
+// mapGatewayToCertificateImports enqueues every ACMCertificateImport in obj's
+// namespace that references it as a GatewayRef.
+func (r *ACMCertificateImportReconciler) mapGatewayToCertificateImports(obj client.Object) []ctrl.Request {
+	return r.mapRefToCertificateImports(obj, func(certificateImport acmv1alpha1.ACMCertificateImport) []corev1.LocalObjectReference {
+		return certificateImport.Spec.GatewayRefs
+	})
+}
+
+// mapSecretToCertificateImports enqueues every ACMCertificateImport in obj's
+// namespace whose SecretRef points at it, so a Secret rotation is reconciled
+// as soon as it's observed rather than waiting for the next renewal poll.
+func (r *ACMCertificateImportReconciler) mapSecretToCertificateImports(obj client.Object) []ctrl.Request {
+	ctx := context.Background()
+
+	var certificateImports acmv1alpha1.ACMCertificateImportList
+	if err := r.List(ctx, &certificateImports, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "could not list ACMCertificateImports")
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, certificateImport := range certificateImports.Items {
+		if certificateImport.Spec.SecretRef.Name == obj.GetName() {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&certificateImport)})
+		}
+	}
+	return requests
+}
+
+// mapRefToCertificateImports lists the ACMCertificateImports in obj's
+// namespace and enqueues the ones whose refs (selected by the refs func)
+// include obj's name.
+func (r *ACMCertificateImportReconciler) mapRefToCertificateImports(obj client.Object, refs func(acmv1alpha1.ACMCertificateImport) []corev1.LocalObjectReference) []ctrl.Request {
+	ctx := context.Background()
+
+	var certificateImports acmv1alpha1.ACMCertificateImportList
+	if err := r.List(ctx, &certificateImports, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "could not list ACMCertificateImports")
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, certificateImport := range certificateImports.Items {
+		for _, ref := range refs(certificateImport) {
+			if ref.Name == obj.GetName() {
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&certificateImport)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// workerCountOrDefault returns n, or 1 if n is zero.
+func workerCountOrDefault(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+
+	acmv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/v1alpha1"
+)
+
+// ACMIssuerReconciler reconciles a ACMIssuer object, validating that the
+// configured AWS region and credentials are usable before the issuer can be
+// referenced by a CertificateRequest.
+type ACMIssuerReconciler struct {
+	client.Client
+	Clients *ClientFactory
+	Scheme  *runtime.Scheme
+
+	// WorkerCount is the number of concurrent reconciles to run, sourced from
+	// ControllerConfiguration.Controllers.ACMIssuer.WorkerCount. A value of
+	// zero defaults to 1.
+	WorkerCount int
+
+	// ResyncPeriod causes the issuer's credentials to be re-validated periodically even without a
+	// triggering change, sourced from ControllerConfiguration.Controllers.ACMIssuer.ResyncPeriod. Zero disables it.
+	ResyncPeriod time.Duration
+}
+
+//+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmissuers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmissuers/status,verbs=get;update;patch
+
+// Reconcile validates the ACMIssuer and reports the result as the Ready condition.
+func (r *ACMIssuerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Get the ACMIssuer object
+	var issuer acmv1alpha1.ACMIssuer
+	if err := r.Get(ctx, req.NamespacedName, &issuer); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Validate the issuer and set the Ready condition accordingly
+	setReadyCondition(&issuer.Status.Conditions, validateIssuer(ctx, r.Clients.Get(issuer.Spec.Region, "", ""), issuer.Spec))
+	return requeueOrResync(ctrl.Result{}, r.Status().Update(ctx, &issuer), r.ResyncPeriod)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ACMIssuerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&acmv1alpha1.ACMIssuer{}).
+		WithOptions(ctrlcontroller.Options{MaxConcurrentReconciles: workerCountOrDefault(r.WorkerCount)}).
+		Complete(r)
+}
+
+// ACMClusterIssuerReconciler reconciles a ACMClusterIssuer object. It behaves
+// identically to ACMIssuerReconciler but operates on the cluster-scoped kind.
+type ACMClusterIssuerReconciler struct {
+	client.Client
+	Clients *ClientFactory
+	Scheme  *runtime.Scheme
+
+	// WorkerCount is the number of concurrent reconciles to run, sourced from
+	// ControllerConfiguration.Controllers.ACMIssuer.WorkerCount. A value of
+	// zero defaults to 1.
+	WorkerCount int
+
+	// ResyncPeriod causes the issuer's credentials to be re-validated periodically even without a
+	// triggering change, sourced from ControllerConfiguration.Controllers.ACMIssuer.ResyncPeriod. Zero disables it.
+	ResyncPeriod time.Duration
+}
+
+//+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmclusterissuers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmclusterissuers/status,verbs=get;update;patch
+
+// Reconcile validates the ACMClusterIssuer and reports the result as the Ready condition.
+func (r *ACMClusterIssuerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Get the ACMClusterIssuer object
+	var issuer acmv1alpha1.ACMClusterIssuer
+	if err := r.Get(ctx, req.NamespacedName, &issuer); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Validate the issuer and set the Ready condition accordingly
+	setReadyCondition(&issuer.Status.Conditions, validateIssuer(ctx, r.Clients.Get(issuer.Spec.Region, "", ""), issuer.Spec))
+	return requeueOrResync(ctrl.Result{}, r.Status().Update(ctx, &issuer), r.ResyncPeriod)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ACMClusterIssuerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&acmv1alpha1.ACMClusterIssuer{}).
+		WithOptions(ctrlcontroller.Options{MaxConcurrentReconciles: workerCountOrDefault(r.WorkerCount)}).
+		Complete(r)
+}
+
+// validateIssuer checks that ACM can be reached through acmClient, which uses
+// the controller's credentials in the issuer's spec.Region (the controller's
+// default region when that is empty), returning a non-nil error describing
+// why the issuer is not ready.
+func validateIssuer(ctx context.Context, acmClient acmiface.ACMAPI, spec acmv1alpha1.ACMIssuerSpec) error {
+	// A cheap, read-only call is enough to prove the credentials and region work
+	_, err := acmClient.ListCertificatesWithContext(ctx, &acm.ListCertificatesInput{MaxItems: aws.Int64(1)})
+	if err != nil {
+		return fmt.Errorf("could not list certificates in region %q using the configured AWS credentials: %w", spec.Region, err)
+	}
+
+	// No error, the issuer is ready
+	return nil
+}
+
+// setReadyCondition sets the Ready condition on conditions based on err.
+func setReadyCondition(conditions *[]metav1.Condition, err error) {
+	// If validation failed, report the error on the Ready condition
+	if err != nil {
+		apimeta.SetStatusCondition(conditions, metav1.Condition{
+			Type:    acmv1alpha1.ConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ValidationFailed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Validation succeeded, the issuer can be used
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    acmv1alpha1.ConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Validated",
+		Message: "the AWS region and credentials have been validated",
+	})
+}
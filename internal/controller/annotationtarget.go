@@ -0,0 +1,261 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	acmv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/v1alpha1"
+)
+
+// IngressAnnotation is set on Ingresses so AWS Load Balancer Controller
+// terminates TLS for them using the imported certificate.
+const IngressAnnotation = "alb.ingress.kubernetes.io/certificate-arn"
+
+// GatewayTLSOption is the listener TLS option key used to point a Gateway
+// API listener at an ACM certificate ARN.
+const GatewayTLSOption = "acm.kubespress.com/certificate-arn"
+
+// AnnotationTarget is implemented by every kind of resource the controller
+// can point at an ACM certificate ARN (Services, Ingresses, Gateways, ...).
+type AnnotationTarget interface {
+	// Apply sets arn on the target. The Service and Ingress targets leave an
+	// annotation that is already present untouched, whatever ARN it holds; the
+	// Gateway target overwrites any other ARN on its TLS listeners.
+	Apply(ctx context.Context, arn string) error
+	// Remove clears arn from the target, it is a no-op if arn is not set,
+	// or if the target no longer exists.
+	Remove(ctx context.Context, arn string) error
+}
+
+// targetsFor returns the AnnotationTarget for every ServiceRef, IngressRef
+// and GatewayRef on certificateImport.
+func (r *ACMCertificateImportReconciler) targetsFor(certificateImport *acmv1alpha1.ACMCertificateImport) []AnnotationTarget {
+	namespace := certificateImport.Namespace
+	targets := make([]AnnotationTarget, 0, len(certificateImport.Spec.ServiceRefs)+len(certificateImport.Spec.IngressRefs)+len(certificateImport.Spec.GatewayRefs))
+
+	for _, ref := range certificateImport.Spec.ServiceRefs {
+		targets = append(targets, &serviceTarget{Client: r.Client, namespace: namespace, name: ref.Name})
+	}
+	for _, ref := range certificateImport.Spec.IngressRefs {
+		targets = append(targets, &ingressTarget{Client: r.Client, namespace: namespace, name: ref.Name})
+	}
+	for _, ref := range certificateImport.Spec.GatewayRefs {
+		targets = append(targets, &gatewayTarget{Client: r.Client, namespace: namespace, name: ref.Name})
+	}
+
+	return targets
+}
+
+// ensureAnnotationTargets applies arn to every target referenced by certificateImport.
+func (r *ACMCertificateImportReconciler) ensureAnnotationTargets(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport, arn string) error {
+	var errs []error
+	for _, target := range r.targetsFor(certificateImport) {
+		if err := target.Apply(ctx, arn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// removeAnnotationTargets clears arn from every target referenced by certificateImport.
+func (r *ACMCertificateImportReconciler) removeAnnotationTargets(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport, arn string) error {
+	var errs []error
+	for _, target := range r.targetsFor(certificateImport) {
+		if err := target.Remove(ctx, arn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// jsonPatchSetAnnotation builds a JSON patch that replaces key with value.
+func jsonPatchSetAnnotation(key, value string) []byte {
+	return []byte(fmt.Sprintf(`[{"op": "replace", "path": "/metadata/annotations/%s", "value": %q}]`, escapeJSONPatchKey(key), value))
+}
+
+// jsonPatchRemoveAnnotation builds a JSON patch that removes key.
+func jsonPatchRemoveAnnotation(key string) []byte {
+	return []byte(fmt.Sprintf(`[{"op": "remove", "path": "/metadata/annotations/%s"}]`, escapeJSONPatchKey(key)))
+}
+
+// escapeJSONPatchKey escapes "~" and "/" per RFC 6901 so key is safe to use
+// as a JSON Pointer path segment.
+func escapeJSONPatchKey(key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	key = strings.ReplaceAll(key, "/", "~1")
+	return key
+}
+
+// serviceTarget points the ServiceAnnotation annotation of a Service at an ACM certificate.
+type serviceTarget struct {
+	client.Client
+	namespace, name string
+}
+
+func (t *serviceTarget) Apply(ctx context.Context, arn string) error {
+	var service corev1.Service
+	if err := t.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, &service); err != nil {
+		return fmt.Errorf("could not get service %q: %w", t.name, err)
+	}
+
+	if _, exists := service.Annotations[ServiceAnnotation]; exists {
+		return nil
+	}
+
+	if err := t.Patch(ctx, &service, client.RawPatch(types.JSONPatchType, jsonPatchSetAnnotation(ServiceAnnotation, arn)), client.FieldOwner(FieldOwner)); err != nil {
+		return fmt.Errorf("could not patch service %q annotation: %w", t.name, err)
+	}
+	return nil
+}
+
+func (t *serviceTarget) Remove(ctx context.Context, arn string) error {
+	var service corev1.Service
+	if err := t.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, &service); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("could not get service %q: %w", t.name, err)
+	}
+
+	if service.Annotations[ServiceAnnotation] != arn {
+		return nil
+	}
+
+	if err := t.Patch(ctx, &service, client.RawPatch(types.JSONPatchType, jsonPatchRemoveAnnotation(ServiceAnnotation)), client.FieldOwner(FieldOwner)); err != nil {
+		return fmt.Errorf("could not patch service %q annotation: %w", t.name, err)
+	}
+	return nil
+}
+
+// ingressTarget points the IngressAnnotation annotation of an ALB Ingress at an ACM certificate.
+type ingressTarget struct {
+	client.Client
+	namespace, name string
+}
+
+func (t *ingressTarget) Apply(ctx context.Context, arn string) error {
+	var ingress networkingv1.Ingress
+	if err := t.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, &ingress); err != nil {
+		return fmt.Errorf("could not get ingress %q: %w", t.name, err)
+	}
+
+	if _, exists := ingress.Annotations[IngressAnnotation]; exists {
+		return nil
+	}
+
+	if err := t.Patch(ctx, &ingress, client.RawPatch(types.JSONPatchType, jsonPatchSetAnnotation(IngressAnnotation, arn)), client.FieldOwner(FieldOwner)); err != nil {
+		return fmt.Errorf("could not patch ingress %q annotation: %w", t.name, err)
+	}
+	return nil
+}
+
+func (t *ingressTarget) Remove(ctx context.Context, arn string) error {
+	var ingress networkingv1.Ingress
+	if err := t.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, &ingress); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("could not get ingress %q: %w", t.name, err)
+	}
+
+	if ingress.Annotations[IngressAnnotation] != arn {
+		return nil
+	}
+
+	if err := t.Patch(ctx, &ingress, client.RawPatch(types.JSONPatchType, jsonPatchRemoveAnnotation(IngressAnnotation)), client.FieldOwner(FieldOwner)); err != nil {
+		return fmt.Errorf("could not patch ingress %q annotation: %w", t.name, err)
+	}
+	return nil
+}
+
+// gatewayTarget points every TLS listener of a Gateway API Gateway at an ACM certificate, using the
+// GatewayTLSOption listener option since Gateway API has no native way to reference an ACM ARN directly.
+type gatewayTarget struct {
+	client.Client
+	namespace, name string
+}
+
+func (t *gatewayTarget) Apply(ctx context.Context, arn string) error {
+	var gateway gatewayv1.Gateway
+	if err := t.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, &gateway); err != nil {
+		return fmt.Errorf("could not get gateway %q: %w", t.name, err)
+	}
+
+	changed := false
+	for i, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+		if gateway.Spec.Listeners[i].TLS.Options == nil {
+			gateway.Spec.Listeners[i].TLS.Options = map[gatewayv1.AnnotationKey]gatewayv1.AnnotationValue{}
+		}
+		if gateway.Spec.Listeners[i].TLS.Options[GatewayTLSOption] == gatewayv1.AnnotationValue(arn) {
+			continue
+		}
+		gateway.Spec.Listeners[i].TLS.Options[GatewayTLSOption] = gatewayv1.AnnotationValue(arn)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := t.Update(ctx, &gateway); err != nil {
+		return fmt.Errorf("could not update gateway %q listeners: %w", t.name, err)
+	}
+	return nil
+}
+
+func (t *gatewayTarget) Remove(ctx context.Context, arn string) error {
+	var gateway gatewayv1.Gateway
+	if err := t.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, &gateway); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("could not get gateway %q: %w", t.name, err)
+	}
+
+	changed := false
+	for i, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+		if gateway.Spec.Listeners[i].TLS.Options[GatewayTLSOption] != gatewayv1.AnnotationValue(arn) {
+			continue
+		}
+		delete(gateway.Spec.Listeners[i].TLS.Options, GatewayTLSOption)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := t.Update(ctx, &gateway); err != nil {
+		return fmt.Errorf("could not update gateway %q listeners: %w", t.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	apiutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/acmpca"
+	"github.com/aws/aws-sdk-go/service/acmpca/acmpcaiface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	acmv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/v1alpha1"
+)
+
+// IssuerGroup is the API group external CertificateRequests must set on their
+// issuerRef for this controller to act on them.
+const IssuerGroup = "acm.kubespress.com"
+
+// IssuerKindACMIssuer and IssuerKindACMClusterIssuer are the issuerRef kinds
+// this controller treats as external cert-manager issuers.
+const (
+	IssuerKindACMIssuer        = "ACMIssuer"
+	IssuerKindACMClusterIssuer = "ACMClusterIssuer"
+)
+
+// certificateARNAnnotation records the ACM Private CA certificate ARN a
+// CertificateRequest was submitted as, so a later reconcile can poll it
+// instead of requesting a new certificate.
+const certificateARNAnnotation = "acm.kubespress.com/certificate-arn"
+
+// requeuePendingInterval controls how often a CertificateRequest is polled
+// while ACM Private CA is still issuing the certificate.
+const requeuePendingInterval = 15 * time.Second
+
+// CertificateRequestReconciler implements the cert-manager external issuer
+// contract, satisfying CertificateRequests that reference an ACMIssuer or
+// ACMClusterIssuer from ACM Private CA.
+type CertificateRequestReconciler struct {
+	client.Client
+	Clients *PCAClientFactory
+	Scheme  *runtime.Scheme
+
+	// DefaultTags are applied to every certificate this controller requests from ACM Private CA,
+	// sourced from ControllerConfiguration.AWS.Tags.
+	DefaultTags map[string]string
+
+	// WorkerCount is the number of concurrent reconciles to run, sourced from
+	// ControllerConfiguration.Controllers.CertificateRequest.WorkerCount. A
+	// value of zero defaults to 1.
+	WorkerCount int
+
+	// ResyncPeriod causes a periodic reconcile even without a triggering change, sourced from
+	// ControllerConfiguration.Controllers.CertificateRequest.ResyncPeriod. Zero disables it.
+	ResyncPeriod time.Duration
+}
+
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificaterequests,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificaterequests/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=acm.kubespress.com,resources=acmissuers;acmclusterissuers,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Get the CertificateRequest object
+	var cr cmapi.CertificateRequest
+	if err := r.Get(ctx, req.NamespacedName, &cr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Ignore requests that don't reference one of our issuers
+	if cr.Spec.IssuerRef.Group != IssuerGroup {
+		return ctrl.Result{}, nil
+	}
+
+	// Ignore requests that have already reached a terminal Ready state. Issued is terminal, as are Denied and
+	// Failed, but False/Pending is not: requestCertificate sets that reason while waiting for reconcileIssuance
+	// to poll ACM Private CA, and it must not be mistaken for a terminal state or issuance would never complete.
+	if ready := certificateRequestReadyCondition(&cr); ready != nil && certificateRequestReasonIsTerminal(ready) {
+		return requeueOrResync(ctrl.Result{}, nil, r.ResyncPeriod)
+	}
+
+	// The cert-manager external issuer contract requires us to refuse denied requests
+	if apiutil.CertificateRequestIsDenied(&cr) {
+		setCertificateRequestCondition(&cr, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonDenied, "the certificaterequest was denied")
+		return ctrl.Result{}, r.Status().Update(ctx, &cr)
+	}
+
+	// ...and to wait for approval before issuing. cert-manager re-reconciles us once that changes.
+	if !apiutil.CertificateRequestIsApproved(&cr) {
+		return ctrl.Result{}, nil
+	}
+
+	// Resolve the issuer and make sure it has validated its AWS credentials
+	pcaClient, caARN, err := r.resolveIssuer(ctx, req.Namespace, cr.Spec.IssuerRef)
+	if err != nil {
+		setCertificateRequestCondition(&cr, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending, err.Error())
+		return ctrl.Result{RequeueAfter: requeuePendingInterval}, r.Status().Update(ctx, &cr)
+	}
+
+	// Submit the request to ACM Private CA if we haven't already
+	arn, submitted := cr.Annotations[certificateARNAnnotation]
+	if !submitted {
+		return ctrl.Result{}, r.requestCertificate(ctx, &cr, pcaClient, caARN)
+	}
+
+	// Poll ACM Private CA for the issued certificate
+	return r.reconcileIssuance(ctx, &cr, pcaClient, caARN, arn)
+}
+
+// resolveIssuer gets the issuer referenced by ref and returns the ACM Private CA client and CA ARN it is
+// configured with, or an error if the issuer is missing, not ready, or not backed by a CA. The returned client
+// targets the issuer's spec.Region, so CertificateRequests for issuers in different regions are each sent to
+// the right ACM Private CA endpoint.
+func (r *CertificateRequestReconciler) resolveIssuer(ctx context.Context, namespace string, ref cmmeta.ObjectReference) (acmpcaiface.ACMPCAAPI, string, error) {
+	var spec acmv1alpha1.ACMIssuerSpec
+	var ready bool
+
+	switch ref.Kind {
+	case IssuerKindACMIssuer:
+		var issuer acmv1alpha1.ACMIssuer
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &issuer); err != nil {
+			return nil, "", fmt.Errorf("could not get ACMIssuer %q: %w", ref.Name, err)
+		}
+		spec, ready = issuer.Spec, conditionReady(issuer.Status.Conditions)
+	case IssuerKindACMClusterIssuer:
+		var issuer acmv1alpha1.ACMClusterIssuer
+		if err := r.Get(ctx, client.ObjectKey{Name: ref.Name}, &issuer); err != nil {
+			return nil, "", fmt.Errorf("could not get ACMClusterIssuer %q: %w", ref.Name, err)
+		}
+		spec, ready = issuer.Spec, conditionReady(issuer.Status.Conditions)
+	default:
+		return nil, "", fmt.Errorf("unsupported issuerRef kind %q", ref.Kind)
+	}
+
+	// The issuer must have validated its credentials before we trust it
+	if !ready {
+		return nil, "", fmt.Errorf("issuer %q is not Ready", ref.Name)
+	}
+
+	// ACM's public issuance API cannot be handed an external CSR, so a
+	// Private CA is required to satisfy CertificateRequests
+	if spec.CertificateAuthorityARN == nil {
+		return nil, "", fmt.Errorf("issuer %q has no certificateAuthorityARN configured", ref.Name)
+	}
+
+	return r.Clients.Get(spec.Region, "", ""), *spec.CertificateAuthorityARN, nil
+}
+
+// requestCertificate submits cr's CSR to ACM Private CA and records the
+// returned certificate ARN as an annotation so it can be polled later.
+func (r *CertificateRequestReconciler) requestCertificate(ctx context.Context, cr *cmapi.CertificateRequest, pcaClient acmpcaiface.ACMPCAAPI, caARN string) error {
+	// Submit the CSR as-is, ACM Private CA signs it without needing the key. IdempotencyToken is the
+	// CertificateRequest's UID so a retried reconcile (e.g. after the Update below conflicts) gets back the
+	// same certificate ARN instead of having ACM Private CA issue a duplicate certificate.
+	input := acmpca.IssueCertificateInput{
+		CertificateAuthorityArn: aws.String(caARN),
+		Csr:                     cr.Spec.Request,
+		SigningAlgorithm:        aws.String(acmpca.SigningAlgorithmSha256withrsa),
+		Validity:                certificateValidity(cr.Spec.Duration),
+		IdempotencyToken:        aws.String(string(cr.UID)),
+		Tags:                    r.tags(),
+	}
+
+	log.FromContext(ctx).Info("requesting certificate from acm private ca", "certificateAuthorityArn", caARN)
+	output, err := pcaClient.IssueCertificateWithContext(ctx, &input)
+	if err != nil {
+		setCertificateRequestCondition(cr, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed, err.Error())
+		return client.IgnoreNotFound(r.Status().Update(ctx, cr))
+	}
+
+	// Record the certificate ARN so the next reconcile polls it
+	if cr.Annotations == nil {
+		cr.Annotations = map[string]string{}
+	}
+	cr.Annotations[certificateARNAnnotation] = aws.StringValue(output.CertificateArn)
+	if err := r.Update(ctx, cr); err != nil {
+		return err
+	}
+
+	setCertificateRequestCondition(cr, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending, "waiting for acm private ca to issue the certificate")
+	return r.Status().Update(ctx, cr)
+}
+
+// reconcileIssuance polls ACM Private CA for the certificate identified by
+// arn and, once issued, writes it to cr's status.
+func (r *CertificateRequestReconciler) reconcileIssuance(ctx context.Context, cr *cmapi.CertificateRequest, pcaClient acmpcaiface.ACMPCAAPI, caARN, arn string) (ctrl.Result, error) {
+	output, err := pcaClient.GetCertificateWithContext(ctx, &acmpca.GetCertificateInput{
+		CertificateAuthorityArn: aws.String(caARN),
+		CertificateArn:          aws.String(arn),
+	})
+
+	// ACM Private CA hasn't finished issuing the certificate yet, requeue
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == acmpca.ErrCodeRequestInProgressException {
+		return ctrl.Result{RequeueAfter: requeuePendingInterval}, nil
+	}
+
+	// Any other error is terminal for this request
+	if err != nil {
+		setCertificateRequestCondition(cr, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed, err.Error())
+		return ctrl.Result{}, r.Status().Update(ctx, cr)
+	}
+
+	// Certificate and chain come back as separate PEM blocks, cert-manager
+	// expects the full chain in Status.Certificate and the root in Status.CA
+	leaf := []byte(aws.StringValue(output.Certificate))
+	chain := []byte(aws.StringValue(output.CertificateChain))
+	cr.Status.Certificate = append(bytes.TrimSpace(leaf), append([]byte("\n"), chain...)...)
+	cr.Status.CA = rootCertificate(chain)
+
+	setCertificateRequestCondition(cr, cmmeta.ConditionTrue, cmapi.CertificateRequestReasonIssued, "certificate issued by acm private ca")
+	return requeueOrResync(ctrl.Result{}, r.Status().Update(ctx, cr), r.ResyncPeriod)
+}
+
+// tags converts r.DefaultTags into the []*acmpca.Tag shape IssueCertificateInput expects.
+func (r *CertificateRequestReconciler) tags() []*acmpca.Tag {
+	if len(r.DefaultTags) == 0 {
+		return nil
+	}
+
+	tags := make([]*acmpca.Tag, 0, len(r.DefaultTags))
+	for k, v := range r.DefaultTags {
+		tags = append(tags, &acmpca.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return tags
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CertificateRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&cmapi.CertificateRequest{}).
+		WithOptions(ctrlcontroller.Options{MaxConcurrentReconciles: workerCountOrDefault(r.WorkerCount)}).
+		Complete(r)
+}
+
+// certificateValidity returns the ACM Private CA validity period for
+// duration, defaulting to 90 days when unset.
+func certificateValidity(duration *metav1.Duration) *acmpca.Validity {
+	days := int64(90)
+	if duration != nil {
+		days = int64(duration.Duration / (24 * time.Hour))
+	}
+
+	return &acmpca.Validity{
+		Type:  aws.String(acmpca.ValidityPeriodTypeDays),
+		Value: aws.Int64(days),
+	}
+}
+
+// rootCertificate returns the last PEM certificate block in chain, which is
+// the root CA when ACM Private CA returns a full chain.
+func rootCertificate(chain []byte) []byte {
+	var last []byte
+	for rest := chain; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		last = pem.EncodeToMemory(block)
+	}
+	return last
+}
+
+// certificateRequestReadyCondition returns the Ready condition on cr, or nil
+// if it hasn't been set yet.
+func certificateRequestReadyCondition(cr *cmapi.CertificateRequest) *cmapi.CertificateRequestCondition {
+	for i, c := range cr.Status.Conditions {
+		if c.Type == cmapi.CertificateRequestConditionReady {
+			return &cr.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// certificateRequestReasonIsTerminal reports whether ready reflects an outcome this controller will never
+// revisit: the certificate was Issued, or the request was Denied or Failed. A False/Pending condition is not
+// terminal, it means requestCertificate has submitted the CSR and reconcileIssuance is still polling for it.
+func certificateRequestReasonIsTerminal(ready *cmapi.CertificateRequestCondition) bool {
+	switch ready.Status {
+	case cmmeta.ConditionTrue:
+		return true
+	case cmmeta.ConditionFalse:
+		return ready.Reason == cmapi.CertificateRequestReasonDenied || ready.Reason == cmapi.CertificateRequestReasonFailed
+	default:
+		return false
+	}
+}
+
+// setCertificateRequestCondition sets or updates the Ready condition on cr.
+func setCertificateRequestCondition(cr *cmapi.CertificateRequest, status cmmeta.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	if existing := certificateRequestReadyCondition(cr); existing != nil {
+		existing.Status = status
+		existing.Reason = reason
+		existing.Message = message
+		existing.LastTransitionTime = &now
+		return
+	}
+
+	cr.Status.Conditions = append(cr.Status.Conditions, cmapi.CertificateRequestCondition{
+		Type:               cmapi.CertificateRequestConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: &now,
+	})
+}
+
+// conditionReady reports whether conditions contains a True Ready condition.
+func conditionReady(conditions []metav1.Condition) bool {
+	for _, c := range conditions {
+		if c.Type == acmv1alpha1.ConditionReady {
+			return c.Status == metav1.ConditionTrue
+		}
+	}
+	return false
+}
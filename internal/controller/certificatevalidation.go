@@ -0,0 +1,154 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/cert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	acmv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/v1alpha1"
+)
+
+// certificateClockSkew is added to the current time when checking certificate validity, to tolerate the
+// controller's clock lagging slightly behind whatever issued the certificate in Secret, which would
+// otherwise show up as a false "not yet valid" failure right after issuance.
+const certificateClockSkew = 5 * time.Minute
+
+// validationError is a certificate validation failure with a machine-readable
+// reason suitable for the Degraded condition.
+type validationError struct {
+	reason  string
+	message string
+}
+
+func (e *validationError) Error() string { return e.message }
+
+// resolveTrustBundle returns the x509.CertPool backing certificateImport's
+// TrustBundleRef, or nil if none is configured.
+func (r *ACMCertificateImportReconciler) resolveTrustBundle(ctx context.Context, certificateImport *acmv1alpha1.ACMCertificateImport) (*x509.CertPool, error) {
+	ref := certificateImport.Spec.TrustBundleRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: certificateImport.Namespace, Name: ref.Name}, &secret); err != nil {
+		return nil, fmt.Errorf("could not get trust bundle secret %q: %w", ref.Name, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data["ca.crt"]) {
+		return nil, fmt.Errorf("trust bundle secret %q has no certificates under the %q key", ref.Name, "ca.crt")
+	}
+	return pool, nil
+}
+
+// validateCertificateForACM checks that leaf/chain/key meet ACM's import
+// requirements: the chain must verify, must not include a self-signed root
+// (ACM refuses those), the leaf's key algorithm/size must be one ACM
+// supports, and key must match the leaf's public key. trustBundle is used as
+// the root of trust if set, otherwise the chain's own intermediates are used.
+func validateCertificateForACM(certs []*x509.Certificate, key []byte, trustBundle *x509.CertPool) *validationError {
+	leaf := certs[0]
+	chain := certs[1:]
+
+	for _, c := range chain {
+		if isSelfSigned(c) {
+			return &validationError{
+				reason:  "SelfSignedRootInChain",
+				message: fmt.Sprintf("certificate chain includes self-signed root %q, ACM refuses roots in the imported chain", c.Subject),
+			}
+		}
+	}
+
+	if err := validateKeyAlgorithm(leaf); err != nil {
+		return &validationError{reason: "UnsupportedKeyType", message: err.Error()}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain {
+		intermediates.AddCert(c)
+	}
+	roots := trustBundle
+	if roots == nil {
+		roots = intermediates
+	}
+	// KeyUsages defaults to requiring ExtKeyUsageServerAuth, which ACM itself doesn't require of imported
+	// certificates, so allow any EKU here and only check what ACM actually enforces: that the chain links up.
+	verifyOptions := x509.VerifyOptions{
+		Intermediates: intermediates,
+		Roots:         roots,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		CurrentTime:   time.Now().Add(certificateClockSkew),
+	}
+	if _, err := leaf.Verify(verifyOptions); err != nil {
+		return &validationError{reason: "ChainInvalid", message: fmt.Sprintf("certificate chain does not verify: %s", err)}
+	}
+
+	leafPEM, err := cert.EncodeCertificates(leaf)
+	if err != nil {
+		return &validationError{reason: "ChainInvalid", message: fmt.Sprintf("could not encode leaf certificate: %s", err)}
+	}
+	if _, err := tls.X509KeyPair(leafPEM, key); err != nil {
+		return &validationError{reason: "KeyMismatch", message: fmt.Sprintf("private key does not match certificate: %s", err)}
+	}
+
+	return nil
+}
+
+// isSelfSigned reports whether c's issuer and subject are the same and c is
+// signed by its own public key.
+func isSelfSigned(c *x509.Certificate) bool {
+	if !bytes.Equal(c.RawIssuer, c.RawSubject) {
+		return false
+	}
+	return c.CheckSignatureFrom(c) == nil
+}
+
+// validateKeyAlgorithm enforces ACM's supported key algorithms and sizes:
+// RSA 1024/2048/3072/4096 and ECDSA P-256/P-384/P-521.
+func validateKeyAlgorithm(leaf *x509.Certificate) error {
+	switch pub := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		switch pub.N.BitLen() {
+		case 1024, 2048, 3072, 4096:
+			return nil
+		default:
+			return fmt.Errorf("ACM does not support %d-bit RSA keys, use 1024, 2048, 3072 or 4096", pub.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return nil
+		default:
+			return fmt.Errorf("ACM does not support ECDSA keys on curve %s", pub.Curve.Params().Name)
+		}
+	default:
+		return fmt.Errorf("ACM only supports RSA and ECDSA keys, got %T", pub)
+	}
+}
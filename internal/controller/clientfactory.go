@@ -0,0 +1,141 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	"github.com/aws/aws-sdk-go/service/acmpca"
+	"github.com/aws/aws-sdk-go/service/acmpca/acmpcaiface"
+)
+
+// acmClientKey identifies a cached ACM client by the AWS account/region it
+// targets.
+type acmClientKey struct {
+	region   string
+	roleARN  string
+	endpoint string
+}
+
+// ClientFactory builds and caches acm.ACM clients keyed by (region, assumed
+// role, endpoint), so a single controller instance can import certificates
+// into many AWS accounts and regions without re-authenticating on every
+// reconcile.
+type ClientFactory struct {
+	// Session is the base AWS session clients are derived from, credentials
+	// from it are assumed into RoleARN when one is given.
+	Session *session.Session
+
+	mu      sync.Mutex
+	clients map[acmClientKey]acmiface.ACMAPI
+}
+
+// NewClientFactory returns a ClientFactory that derives clients from sess.
+func NewClientFactory(sess *session.Session) *ClientFactory {
+	return &ClientFactory{Session: sess}
+}
+
+// Get returns the cached ACM client for the given region/roleARN/endpoint,
+// creating one if this is the first time it has been requested. An empty
+// roleARN uses the factory's own credentials, an empty region or endpoint
+// uses the session's defaults.
+func (f *ClientFactory) Get(region, roleARN, endpoint string) acmiface.ACMAPI {
+	key := acmClientKey{region: region, roleARN: roleARN, endpoint: endpoint}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if client, ok := f.clients[key]; ok {
+		return client
+	}
+
+	cfg := aws.Config{}
+	if region != "" {
+		cfg.Region = aws.String(region)
+	}
+	if endpoint != "" {
+		cfg.Endpoint = aws.String(endpoint)
+	}
+	if roleARN != "" {
+		cfg.Credentials = stscreds.NewCredentials(f.Session, roleARN)
+	}
+
+	client := acm.New(f.Session, &cfg)
+	if f.clients == nil {
+		f.clients = map[acmClientKey]acmiface.ACMAPI{}
+	}
+	f.clients[key] = client
+
+	return client
+}
+
+// PCAClientFactory builds and caches acmpca.ACMPCA clients keyed by (region,
+// assumed role, endpoint), so a single controller instance can request
+// certificates from ACM Private CA in many AWS accounts and regions without
+// re-authenticating on every reconcile.
+type PCAClientFactory struct {
+	// Session is the base AWS session clients are derived from, credentials
+	// from it are assumed into RoleARN when one is given.
+	Session *session.Session
+
+	mu      sync.Mutex
+	clients map[acmClientKey]acmpcaiface.ACMPCAAPI
+}
+
+// NewPCAClientFactory returns a PCAClientFactory that derives clients from sess.
+func NewPCAClientFactory(sess *session.Session) *PCAClientFactory {
+	return &PCAClientFactory{Session: sess}
+}
+
+// Get returns the cached ACM Private CA client for the given
+// region/roleARN/endpoint, creating one if this is the first time it has
+// been requested. An empty roleARN uses the factory's own credentials, an
+// empty region or endpoint uses the session's defaults.
+func (f *PCAClientFactory) Get(region, roleARN, endpoint string) acmpcaiface.ACMPCAAPI {
+	key := acmClientKey{region: region, roleARN: roleARN, endpoint: endpoint}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if client, ok := f.clients[key]; ok {
+		return client
+	}
+
+	cfg := aws.Config{}
+	if region != "" {
+		cfg.Region = aws.String(region)
+	}
+	if endpoint != "" {
+		cfg.Endpoint = aws.String(endpoint)
+	}
+	if roleARN != "" {
+		cfg.Credentials = stscreds.NewCredentials(f.Session, roleARN)
+	}
+
+	client := acmpca.New(f.Session, &cfg)
+	if f.clients == nil {
+		f.clients = map[acmClientKey]acmpcaiface.ACMPCAAPI{}
+	}
+	f.clients[key] = client
+
+	return client
+}
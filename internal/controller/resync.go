@@ -0,0 +1,34 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// requeueOrResync returns result unchanged if it already requests a requeue
+// (or carries an error), otherwise it requeues after resyncPeriod so
+// ControllerConfigurationSpec.ResyncPeriod causes periodic re-reconciliation
+// even when nothing else triggers one. A zero resyncPeriod is a no-op.
+func requeueOrResync(result ctrl.Result, err error, resyncPeriod time.Duration) (ctrl.Result, error) {
+	if err != nil || result.Requeue || result.RequeueAfter != 0 || resyncPeriod == 0 {
+		return result, err
+	}
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
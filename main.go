@@ -0,0 +1,190 @@
+/*
+Copyright 2023 Adam Talbot.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	configv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/config/v1alpha1"
+	acmv1alpha1 "github.com/thatsmrtalbot/kube-acm-importer/api/v1alpha1"
+	"github.com/thatsmrtalbot/kube-acm-importer/internal/controller"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(acmv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(cmapi.AddToScheme(scheme))
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
+	// gatewayv1 is registered even though annotationtarget.go is the only thing that reads/writes Gateways,
+	// because ACMCertificateImportReconciler.SetupWithManager unconditionally Watches Gateway objects.
+	utilruntime.Must(gatewayv1.Install(scheme))
+}
+
+func main() {
+	// CLI flags override the matching --config value when given a non-empty address or, for --leader-elect, when true
+	var configFile, metricsAddr, probeAddr string
+	var enableLeaderElection bool
+	flag.StringVar(&configFile, "config", "", "Path to a ControllerConfiguration file. If unset, built-in defaults are used.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", "", "Overrides metrics.bindAddress from --config.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", "", "Overrides health.healthProbeBindAddress from --config.")
+	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Overrides leaderElection.leaderElect from --config.")
+	opts := zap.Options{Development: true}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	// Load the ComponentConfig file, if one was given, then default and validate it
+	var config configv1alpha1.ControllerConfiguration
+	options := ctrl.Options{Scheme: scheme}
+	if configFile != "" {
+		var err error
+		options, err = options.AndFrom(ctrlconfig.File().AtPath(configFile).OfKind(&config))
+		if err != nil {
+			setupLog.Error(err, "unable to load the config file", "path", configFile)
+			os.Exit(1)
+		}
+	}
+	config.Default()
+	if err := config.Validate(); err != nil {
+		setupLog.Error(err, "invalid controller configuration")
+		os.Exit(1)
+	}
+
+	if metricsAddr != "" {
+		options.MetricsBindAddress = metricsAddr
+	}
+	if probeAddr != "" {
+		options.HealthProbeBindAddress = probeAddr
+	}
+	if enableLeaderElection {
+		options.LeaderElection = enableLeaderElection
+	}
+	if len(config.WatchNamespaces) > 0 {
+		options.NewCache = cache.MultiNamespacedCacheBuilder(config.WatchNamespaces)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	// "irsa" and "profile" both rely on the SDK's default credential chain (the former resolves the pod's
+	// IRSA web identity token automatically, the latter reads Profile below); "static" is pinned explicitly
+	// to the AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY environment variables so stray IRSA/profile credentials
+	// on the host can't be picked up by mistake.
+	var staticCreds *credentials.Credentials
+	if config.AWS.CredentialSource == "static" {
+		staticCreds = credentials.NewEnvCredentials()
+	}
+
+	awsSession := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Profile:           config.AWS.Profile,
+		Config: aws.Config{
+			Region:      aws.String(config.AWS.Region),
+			Endpoint:    aws.String(config.AWS.Endpoint),
+			Credentials: staticCreds,
+		},
+	}))
+	acmClients := controller.NewClientFactory(awsSession)
+	pcaClients := controller.NewPCAClientFactory(awsSession)
+
+	if err = (&controller.ACMCertificateImportReconciler{
+		Client:       mgr.GetClient(),
+		Clients:      acmClients,
+		Scheme:       mgr.GetScheme(),
+		Recorder:     mgr.GetEventRecorderFor("acmcertificateimport-controller"),
+		DefaultTags:  config.AWS.Tags,
+		WorkerCount:  config.Controllers.ACMCertificateImport.WorkerCount,
+		ResyncPeriod: config.Controllers.ACMCertificateImport.ResyncPeriod.Duration,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ACMCertificateImport")
+		os.Exit(1)
+	}
+
+	if err = (&controller.ACMIssuerReconciler{
+		Client:       mgr.GetClient(),
+		Clients:      acmClients,
+		Scheme:       mgr.GetScheme(),
+		WorkerCount:  config.Controllers.ACMIssuer.WorkerCount,
+		ResyncPeriod: config.Controllers.ACMIssuer.ResyncPeriod.Duration,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ACMIssuer")
+		os.Exit(1)
+	}
+
+	if err = (&controller.ACMClusterIssuerReconciler{
+		Client:       mgr.GetClient(),
+		Clients:      acmClients,
+		Scheme:       mgr.GetScheme(),
+		WorkerCount:  config.Controllers.ACMIssuer.WorkerCount,
+		ResyncPeriod: config.Controllers.ACMIssuer.ResyncPeriod.Duration,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ACMClusterIssuer")
+		os.Exit(1)
+	}
+
+	if err = (&controller.CertificateRequestReconciler{
+		Client:       mgr.GetClient(),
+		Clients:      pcaClients,
+		Scheme:       mgr.GetScheme(),
+		DefaultTags:  config.AWS.Tags,
+		WorkerCount:  config.Controllers.CertificateRequest.WorkerCount,
+		ResyncPeriod: config.Controllers.CertificateRequest.ResyncPeriod.Duration,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CertificateRequest")
+		os.Exit(1)
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+
+	setupLog.Info("starting manager")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running manager")
+		os.Exit(1)
+	}
+}